@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Effect mutates the screen after the rain has drawn for the frame, so
+// effects can be layered on top of it and of each other.
+type Effect interface {
+	Apply(screen *Screen, frame int)
+}
+
+// zoneProtector is implemented by effects that claim cells the rain should
+// draw around rather than through, such as a static text overlay.
+type zoneProtector interface {
+	Protected(row, col int) bool
+}
+
+// Compositor runs the rain draw first (via drawColumnFrame's protected-zone
+// checks), then layers effects on top of the result in the order given.
+type Compositor struct {
+	effects []Effect
+}
+
+func newCompositor(effects []Effect) *Compositor {
+	return &Compositor{effects: effects}
+}
+
+// Protected reports whether any effect is claiming this cell.
+func (c *Compositor) Protected(row, col int) bool {
+	for _, e := range c.effects {
+		if p, ok := e.(zoneProtector); ok && p.Protected(row, col) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compositor) Apply(screen *Screen, frame int) {
+	for _, e := range c.effects {
+		e.Apply(screen, frame)
+	}
+}
+
+// buildEffect parses one --effect name[:key=val,...] flag value.
+func buildEffect(spec string) (Effect, error) {
+	name, params := parseEffectSpec(spec)
+	switch name {
+	case "sine":
+		return &sineWaveEffect{
+			lambda: paramFloat(params, "lambda", 8),
+			amp:    paramFloat(params, "amp", 2),
+			speed:  paramFloat(params, "speed", 0.1),
+		}, nil
+	case "glitch":
+		return newGlitchEffect(
+			paramFloat(params, "chance", 0.02),
+			int(paramFloat(params, "duration", 6)),
+		), nil
+	case "text":
+		msg := params["message"]
+		if msg == "" {
+			return nil, fmt.Errorf("text effect requires message=..., e.g. --effect text:message=WAKE UP")
+		}
+		return newTextOverlayEffect(msg), nil
+	default:
+		return nil, fmt.Errorf("unknown effect %q", name)
+	}
+}
+
+func parseEffectSpec(spec string) (name string, params map[string]string) {
+	name = spec
+	rest := ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		name, rest = spec[:i], spec[i+1:]
+	}
+
+	params = map[string]string{}
+	for _, pair := range strings.Split(rest, ",") {
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			params[pair[:i]] = pair[i+1:]
+		}
+	}
+	return name, params
+}
+
+func paramFloat(params map[string]string, key string, def float64) float64 {
+	v, ok := params[key]
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+// sineWaveEffect shifts each row sideways by round(sin(row/lambda + t)*amp),
+// producing a CRT-style horizontal wave distortion.
+type sineWaveEffect struct {
+	lambda float64
+	amp    float64
+	speed  float64
+}
+
+func (e *sineWaveEffect) Apply(screen *Screen, frame int) {
+	t := float64(frame) * e.speed
+	for row := 1; row <= screen.height; row++ {
+		shift := int(math.Round(math.Sin(float64(row)/e.lambda+t) * e.amp))
+		if shift != 0 {
+			screen.shiftRow(row, shift)
+		}
+	}
+}
+
+// glitchBurst is a rectangular region rendered in reverse video until
+// expireFrame, after which restore puts back what was there before the
+// burst so cells the rain never redraws (columns outside the active
+// density, background gaps) don't stay stuck in reverse video forever.
+type glitchBurst struct {
+	expireFrame            int
+	row0, col0, row1, col1 int
+	saved                  []cell
+}
+
+// restore writes b's pre-glitch cells back to the screen.
+func (b glitchBurst) restore(screen *Screen) {
+	i := 0
+	for row := b.row0; row <= b.row1; row++ {
+		for col := b.col0; col <= b.col1; col++ {
+			c := b.saved[i]
+			i++
+			if c.ch == 0 {
+				c.ch = ' '
+			}
+			screen.Set(row, col, c.ch, c.sgr)
+		}
+	}
+}
+
+// glitchEffect randomly spawns short-lived glitch bursts across the screen.
+type glitchEffect struct {
+	chance   float64
+	duration int
+	bursts   []glitchBurst
+}
+
+func newGlitchEffect(chance float64, duration int) *glitchEffect {
+	if duration < 1 {
+		duration = 1
+	}
+	return &glitchEffect{chance: chance, duration: duration}
+}
+
+const glitchSGR = "\033[7;38;2;255;255;255m"
+
+func (e *glitchEffect) Apply(screen *Screen, frame int) {
+	live := e.bursts[:0]
+	for _, b := range e.bursts {
+		if b.expireFrame > frame {
+			live = append(live, b)
+		} else {
+			b.restore(screen)
+		}
+	}
+	e.bursts = live
+
+	if rand.Float64() < e.chance {
+		e.bursts = append(e.bursts, e.spawn(screen, frame))
+	}
+
+	for _, b := range e.bursts {
+		for row := b.row0; row <= b.row1; row++ {
+			for col := b.col0; col <= b.col1; col++ {
+				cur, ok := screen.Get(row, col)
+				if !ok {
+					continue
+				}
+				ch := cur.ch
+				if ch == 0 {
+					ch = ' '
+				}
+				screen.Set(row, col, ch, glitchSGR)
+			}
+		}
+	}
+}
+
+func (e *glitchEffect) spawn(screen *Screen, frame int) glitchBurst {
+	w := 3 + rand.Intn(8)
+	if w > screen.width {
+		w = screen.width
+	}
+	h := 1 + rand.Intn(3)
+	if h > screen.height {
+		h = screen.height
+	}
+
+	col0 := 1
+	if screen.width-w > 0 {
+		col0 = rand.Intn(screen.width-w+1) + 1
+	}
+	row0 := 1
+	if screen.height-h > 0 {
+		row0 = rand.Intn(screen.height-h+1) + 1
+	}
+
+	b := glitchBurst{
+		expireFrame: frame + e.duration,
+		row0:        row0,
+		col0:        col0,
+		row1:        row0 + h - 1,
+		col1:        col0 + w - 1,
+	}
+	b.saved = make([]cell, 0, w*h)
+	for row := b.row0; row <= b.row1; row++ {
+		for col := b.col0; col <= b.col1; col++ {
+			c, _ := screen.Get(row, col)
+			b.saved = append(b.saved, c)
+		}
+	}
+	return b
+}
+
+// textOverlayEffect stamps a static message centered on screen and protects
+// its cells so the rain draws around it instead of through it.
+type textOverlayEffect struct {
+	text string
+
+	lastRow, lastCol0, lastLen int
+}
+
+func newTextOverlayEffect(text string) *textOverlayEffect {
+	return &textOverlayEffect{text: text}
+}
+
+const textSGR = "\033[1;38;2;255;255;255m"
+
+func (e *textOverlayEffect) Apply(screen *Screen, frame int) {
+	runes := []rune(e.text)
+	row := screen.height/2 + 1
+	col0 := (screen.width-len(runes))/2 + 1
+	if col0 < 1 {
+		col0 = 1
+	}
+
+	for i, ch := range runes {
+		screen.Set(row, col0+i, ch, textSGR)
+	}
+	e.lastRow, e.lastCol0, e.lastLen = row, col0, len(runes)
+}
+
+func (e *textOverlayEffect) Protected(row, col int) bool {
+	return e.lastLen > 0 && row == e.lastRow && col >= e.lastCol0 && col < e.lastCol0+e.lastLen
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestParseEffectSpec(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantName   string
+		wantParams map[string]string
+	}{
+		{"sine", "sine", map[string]string{}},
+		{"sine:lambda=8,amp=2", "sine", map[string]string{"lambda": "8", "amp": "2"}},
+		{"glitch:chance=0.02,duration=6", "glitch", map[string]string{"chance": "0.02", "duration": "6"}},
+		{"text:message=hi", "text", map[string]string{"message": "hi"}},
+	}
+
+	for _, tt := range tests {
+		name, params := parseEffectSpec(tt.spec)
+		if name != tt.wantName {
+			t.Errorf("parseEffectSpec(%q) name = %q, want %q", tt.spec, name, tt.wantName)
+		}
+		if len(params) != len(tt.wantParams) {
+			t.Errorf("parseEffectSpec(%q) params = %v, want %v", tt.spec, params, tt.wantParams)
+			continue
+		}
+		for k, v := range tt.wantParams {
+			if params[k] != v {
+				t.Errorf("parseEffectSpec(%q) params[%q] = %q, want %q", tt.spec, k, params[k], v)
+			}
+		}
+	}
+}
+
+func TestParamFloat(t *testing.T) {
+	params := map[string]string{"amp": "3.5", "bad": "not-a-number"}
+
+	if got, want := paramFloat(params, "amp", 1), 3.5; got != want {
+		t.Errorf("paramFloat(amp) = %v, want %v", got, want)
+	}
+	if got, want := paramFloat(params, "missing", 1), 1.0; got != want {
+		t.Errorf("paramFloat(missing) = %v, want default %v", got, want)
+	}
+	if got, want := paramFloat(params, "bad", 2), 2.0; got != want {
+		t.Errorf("paramFloat(bad) = %v, want default %v", got, want)
+	}
+}
+
+func TestGlitchEffectRestoresCellsOnExpiry(t *testing.T) {
+	screen := newScreen(10, 10)
+
+	e := newGlitchEffect(1, 2) // chance=1 guarantees a spawn, duration=2 frames
+	e.Apply(screen, 0)         // spawns a burst and glitches its rectangle
+
+	if len(e.bursts) != 1 {
+		t.Fatalf("expected exactly one burst after Apply with chance=1, got %d", len(e.bursts))
+	}
+	b := e.bursts[0]
+
+	if c, _ := screen.Get(b.row0, b.col0); c.sgr != glitchSGR {
+		t.Fatalf("expected burst's top-left cell to be glitched, got sgr %q", c.sgr)
+	}
+
+	// Advance past expireFrame: the burst should be dropped and its
+	// pre-glitch contents restored, not left in reverse video forever (the
+	// symptom for any cell the rain doesn't happen to redraw afterward).
+	e.chance = 0 // don't let this Apply call spawn a fresh burst
+	e.Apply(screen, b.expireFrame)
+
+	if len(e.bursts) != 0 {
+		t.Fatalf("expected the expired burst to be removed, got %d remaining", len(e.bursts))
+	}
+	c, _ := screen.Get(b.row0, b.col0)
+	if c.sgr == glitchSGR {
+		t.Fatal("expected burst's top-left cell to be restored after expiry, still glitched")
+	}
+	if c.ch != ' ' || c.sgr != "" {
+		t.Fatalf("expected cell restored to its pre-glitch blank state, got ch=%q sgr=%q", c.ch, c.sgr)
+	}
+}
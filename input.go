@@ -0,0 +1,75 @@
+package main
+
+import "github.com/ulolol/MatrixRGB/internal/term"
+
+// command represents a single user action decoded from a keypress.
+type command int
+
+const (
+	cmdNone command = iota
+	cmdTogglePause
+	cmdNextTheme
+	cmdSpeedUp
+	cmdSpeedDown
+	cmdDensityUp
+	cmdDensityDown
+	cmdToggleGlyphs
+	cmdQuit
+)
+
+// startInput puts tty into raw mode and spawns a goroutine that decodes
+// keystrokes into commands, feeding them to the returned channel without
+// blocking the render loop.
+func startInput(tty term.Terminal) (<-chan command, error) {
+	if err := tty.SetRaw(); err != nil {
+		return nil, err
+	}
+
+	cmds := make(chan command, 16)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			n, err := tty.Read(buf)
+			if err != nil || n == 0 {
+				return
+			}
+			cmd := decodeKey(buf[0])
+			if cmd == cmdNone {
+				continue
+			}
+			select {
+			case cmds <- cmd:
+			default:
+				// Drop the keypress rather than block input reading.
+			}
+			if cmd == cmdQuit {
+				return
+			}
+		}
+	}()
+
+	return cmds, nil
+}
+
+func decodeKey(b byte) command {
+	switch b {
+	case ' ':
+		return cmdTogglePause
+	case 'c', 'C':
+		return cmdNextTheme
+	case '+', '=':
+		return cmdSpeedUp
+	case '-', '_':
+		return cmdSpeedDown
+	case ']':
+		return cmdDensityUp
+	case '[':
+		return cmdDensityDown
+	case 'g', 'G':
+		return cmdToggleGlyphs
+	case 'q', 'Q', 3: // 3 = Ctrl+C
+		return cmdQuit
+	default:
+		return cmdNone
+	}
+}
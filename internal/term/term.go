@@ -0,0 +1,40 @@
+// Package term abstracts the terminal operations that main.go needs and
+// that differ across platforms: reading the current size, enabling ANSI/VT
+// escape processing, and putting input into raw (per-keystroke) mode.
+package term
+
+import "io"
+
+// Size is a terminal's dimensions in character cells.
+type Size struct {
+	Width  int
+	Height int
+}
+
+// Terminal is the per-platform handle returned by Open. Reads deliver raw
+// keystrokes once SetRaw has been called.
+type Terminal interface {
+	io.ReadCloser
+
+	// GetSize reports the current terminal dimensions, or ok=false if they
+	// could not be determined.
+	GetSize() (Size, bool)
+
+	// EnableVT turns on ANSI escape sequence processing for the output
+	// stream. It is a no-op on platforms where this is already the case.
+	EnableVT() error
+
+	// SetRaw disables line buffering and echo so keystrokes can be read
+	// one at a time. Restore undoes it.
+	SetRaw() error
+	Restore() error
+
+	// Resized delivers a signal each time the terminal size may have
+	// changed: SIGWINCH on unix, a poll ticker on Windows.
+	Resized() <-chan struct{}
+}
+
+// Open acquires the platform terminal handle.
+func Open() (Terminal, error) {
+	return open()
+}
@@ -0,0 +1,139 @@
+//go:build !windows
+
+package term
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// termios mirrors struct termios as used by TCGETS/TCSETS on Linux.
+type termios struct {
+	Iflag  uint32
+	Oflag  uint32
+	Cflag  uint32
+	Lflag  uint32
+	Line   uint8
+	Cc     [19]uint8
+	Ispeed uint32
+	Ospeed uint32
+}
+
+type winsize struct {
+	row    uint16
+	col    uint16
+	xpixel uint16
+	ypixel uint16
+}
+
+const (
+	tcgets = 0x5401
+	tcsets = 0x5402
+)
+
+type unixTerminal struct {
+	tty      *os.File
+	orig     *termios
+	resizeCh chan os.Signal
+	sizeCh   chan struct{}
+}
+
+func open() (Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &unixTerminal{tty: tty}, nil
+}
+
+func (t *unixTerminal) Read(p []byte) (int, error) {
+	return t.tty.Read(p)
+}
+
+func (t *unixTerminal) GetSize() (Size, bool) {
+	ws := &winsize{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, t.tty.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws)))
+	if errno == 0 && ws.col > 0 && ws.row > 0 {
+		return Size{Width: int(ws.col), Height: int(ws.row)}, true
+	}
+	return Size{}, false
+}
+
+// EnableVT is a no-op: unix terminals already interpret ANSI escapes.
+func (t *unixTerminal) EnableVT() error {
+	return nil
+}
+
+func (t *unixTerminal) SetRaw() error {
+	orig, err := ttyGetState(t.tty.Fd())
+	if err != nil {
+		return err
+	}
+	t.orig = orig
+	raw := ttyMakeRaw(orig)
+	return ttySetState(t.tty.Fd(), &raw)
+}
+
+func (t *unixTerminal) Restore() error {
+	if t.orig == nil {
+		return nil
+	}
+	return ttySetState(t.tty.Fd(), t.orig)
+}
+
+func (t *unixTerminal) Resized() <-chan struct{} {
+	if t.sizeCh != nil {
+		return t.sizeCh
+	}
+	t.resizeCh = make(chan os.Signal, 1)
+	signal.Notify(t.resizeCh, syscall.SIGWINCH)
+	t.sizeCh = make(chan struct{}, 1)
+	go func() {
+		for range t.resizeCh {
+			select {
+			case t.sizeCh <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return t.sizeCh
+}
+
+func (t *unixTerminal) Close() error {
+	if t.resizeCh != nil {
+		signal.Stop(t.resizeCh)
+	}
+	return t.tty.Close()
+}
+
+func ttyGetState(fd uintptr) (*termios, error) {
+	var t termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(tcgets), uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return nil, errno
+	}
+	return &t, nil
+}
+
+func ttySetState(fd uintptr, t *termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(tcsets), uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ttyMakeRaw strips the flags that would otherwise buffer input by line,
+// echo keystrokes, or let the kernel intercept control characters.
+func ttyMakeRaw(t *termios) termios {
+	raw := *t
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	return raw
+}
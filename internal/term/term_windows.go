@@ -0,0 +1,162 @@
+//go:build windows
+
+package term
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+	procGetConsoleMode             = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+)
+
+type coord struct {
+	X, Y int16
+}
+
+type smallRect struct {
+	Left, Top, Right, Bottom int16
+}
+
+type consoleScreenBufferInfo struct {
+	Size              coord
+	CursorPosition    coord
+	Attributes        uint16
+	Window            smallRect
+	MaximumWindowSize coord
+}
+
+const (
+	enableVirtualTerminalProcessing = 0x0004
+	enableEchoInput                 = 0x0004
+	enableLineInput                 = 0x0002
+	enableProcessedInput            = 0x0001
+)
+
+// resizePollInterval is how often Resized checks for a size change, since
+// Windows consoles have no SIGWINCH equivalent.
+const resizePollInterval = 500 * time.Millisecond
+
+type windowsTerminal struct {
+	out syscall.Handle
+	in  syscall.Handle
+
+	origInMode uint32
+
+	sizeCh   chan struct{}
+	pollStop chan struct{}
+}
+
+func open() (Terminal, error) {
+	out, err := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+	if err != nil {
+		return nil, err
+	}
+	in, err := syscall.GetStdHandle(syscall.STD_INPUT_HANDLE)
+	if err != nil {
+		return nil, err
+	}
+	return &windowsTerminal{out: out, in: in}, nil
+}
+
+// Read delivers keystrokes from the console input handle, which is the
+// same stream os.Stdin wraps once SetRaw has switched it to raw mode.
+func (t *windowsTerminal) Read(p []byte) (int, error) {
+	var n uint32
+	if err := syscall.ReadFile(t.in, p, &n, nil); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (t *windowsTerminal) GetSize() (Size, bool) {
+	var info consoleScreenBufferInfo
+	ret, _, _ := procGetConsoleScreenBufferInfo.Call(uintptr(t.out), uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return Size{}, false
+	}
+	width := int(info.Window.Right-info.Window.Left) + 1
+	height := int(info.Window.Bottom-info.Window.Top) + 1
+	return Size{Width: width, Height: height}, true
+}
+
+func (t *windowsTerminal) EnableVT() error {
+	var mode uint32
+	if ret, _, err := procGetConsoleMode.Call(uintptr(t.out), uintptr(unsafe.Pointer(&mode))); ret == 0 {
+		return err
+	}
+	mode |= enableVirtualTerminalProcessing
+	if ret, _, err := procSetConsoleMode.Call(uintptr(t.out), uintptr(mode)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+func (t *windowsTerminal) SetRaw() error {
+	var mode uint32
+	if ret, _, err := procGetConsoleMode.Call(uintptr(t.in), uintptr(unsafe.Pointer(&mode))); ret == 0 {
+		return err
+	}
+	t.origInMode = mode
+	raw := mode &^ (enableEchoInput | enableLineInput | enableProcessedInput)
+	if ret, _, err := procSetConsoleMode.Call(uintptr(t.in), uintptr(raw)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+func (t *windowsTerminal) Restore() error {
+	if t.origInMode == 0 {
+		return nil
+	}
+	if ret, _, err := procSetConsoleMode.Call(uintptr(t.in), uintptr(t.origInMode)); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// Resized polls GetSize on an interval and reports a change, since Windows
+// consoles have no SIGWINCH equivalent to hook into.
+func (t *windowsTerminal) Resized() <-chan struct{} {
+	if t.sizeCh != nil {
+		return t.sizeCh
+	}
+	t.sizeCh = make(chan struct{}, 1)
+	t.pollStop = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(resizePollInterval)
+		defer ticker.Stop()
+
+		last, _ := t.GetSize()
+		for {
+			select {
+			case <-t.pollStop:
+				return
+			case <-ticker.C:
+				cur, ok := t.GetSize()
+				if ok && cur != last {
+					last = cur
+					select {
+					case t.sizeCh <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	return t.sizeCh
+}
+
+func (t *windowsTerminal) Close() error {
+	if t.pollStop != nil {
+		close(t.pollStop)
+	}
+	return nil
+}
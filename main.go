@@ -10,7 +10,8 @@ import (
 	"strconv"
 	"syscall"
 	"time"
-	"unsafe"
+
+	"github.com/ulolol/MatrixRGB/internal/term"
 )
 
 const (
@@ -32,25 +33,45 @@ var katakanaChars = []rune{
 	'ﾘ', 'ﾜ', 'ﾞ', 'ﾟ',
 }
 
+// asciiGlyphChars is the alternate glyph set toggled on with 'g'.
+var asciiGlyphChars = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+
 type column struct {
 	active      bool
 	head        int
 	gap         int
 	length      int
 	colorOffset int
-	lastChar    rune
+	// trail holds the glyphs currently on screen for this column, newest
+	// (the head) first, so the palette can color each position by its age.
+	trail []rune
 }
 
-type winsize struct {
-	row    uint16
-	col    uint16
-	xpixel uint16
-	ypixel uint16
+// pushHead shifts the trail down by one row and inserts ch as the new head.
+func (c *column) pushHead(ch rune) {
+	length := c.length
+	if length < 1 {
+		length = 1
+	}
+	if cap(c.trail) < length {
+		grown := make([]rune, len(c.trail), length)
+		copy(grown, c.trail)
+		c.trail = grown
+	}
+	if len(c.trail) < length {
+		c.trail = append(c.trail, 0)
+	}
+	copy(c.trail[1:], c.trail)
+	c.trail[0] = ch
 }
 
 type config struct {
-	speed   int
-	density int
+	speed       int
+	density     int
+	paletteSpec string
+	effectSpecs []string
+	message     string
+	metricsAddr string
 }
 
 func main() {
@@ -69,27 +90,69 @@ func main() {
 
 	rand.Seed(time.Now().UnixNano())
 
-	rainbowTable := buildRainbowTable(rainbowFreq, rainbowCycle)
-	frameDelay := calculateFrameDelay(cfg.speed)
+	palette, err := newPalette(cfg.paletteSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var effects []Effect
+	for _, spec := range cfg.effectSpecs {
+		effect, err := buildEffect(spec)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		effects = append(effects, effect)
+	}
+	// --message is applied directly rather than round-tripped through
+	// buildEffect/parseEffectSpec, since a message containing a ',' would
+	// otherwise be mangled by the comma-delimited key=val parsing.
+	if cfg.message != "" {
+		effects = append(effects, newTextOverlayEffect(cfg.message))
+	}
+	compositor := newCompositor(effects)
+
+	var m *Metrics
+	if cfg.metricsAddr != "" {
+		m = newMetrics()
+		if err := startMetricsServer(cfg.metricsAddr, m); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
-	width, height := getTerminalSize()
-	width, height = clampDimensions(width, height)
+	tty, err := term.Open()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer tty.Close()
+	if err := tty.EnableVT(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: failed to enable ANSI escape processing:", err)
+	}
 
-	numColumns := calculateColumnCount(width, cfg.density)
-	columns := initColumns(numColumns, height, len(rainbowTable))
+	width, height := getTerminalSize(tty)
 
 	writer := bufio.NewWriter(os.Stdout)
 	setupTerminal(writer)
 	defer restoreTerminal(writer)
 
-	resizeCh := make(chan os.Signal, 1)
-	signal.Notify(resizeCh, syscall.SIGWINCH)
-	defer signal.Stop(resizeCh)
+	renderer := newRenderer(writer, cfg, width, height, palette, compositor, m)
+
+	resizeCh := tty.Resized()
 
 	interruptCh := make(chan os.Signal, 1)
 	signal.Notify(interruptCh, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(interruptCh)
 
+	cmds, err := startInput(tty)
+	if err != nil {
+		// Fall back to a fire-and-forget run: no usable tty means no keys.
+		cmds = make(chan command)
+	}
+	defer tty.Restore()
+
 loop:
 	for {
 		select {
@@ -98,6 +161,18 @@ loop:
 		default:
 		}
 
+	drainCommands:
+		for {
+			select {
+			case cmd := <-cmds:
+				if !renderer.applyCommand(cmd) {
+					break loop
+				}
+			default:
+				break drainCommands
+			}
+		}
+
 		resized := false
 		for {
 			select {
@@ -109,35 +184,31 @@ loop:
 		}
 	resizeDone:
 		if resized {
-			width, height = getTerminalSize()
-			width, height = clampDimensions(width, height)
-			numColumns = calculateColumnCount(width, cfg.density)
-			columns = initColumns(numColumns, height, len(rainbowTable))
+			width, height = getTerminalSize(tty)
+			renderer.resize(width, height)
 			clearScreen(writer)
 			writer.Flush()
 		}
 
 		frameStart := time.Now()
 
-		for idx := range columns {
-			drawColumnFrame(writer, idx, &columns[idx], height, rainbowTable)
-		}
-
-		writer.WriteString("\033[0m")
-		writer.Flush()
+		renderer.drawFrame()
 
 		elapsed := time.Since(frameStart)
-		sleepFor := frameDelay - elapsed
+		sleepFor := renderer.frameDelay() - elapsed
 		if sleepFor > 0 {
 			time.Sleep(sleepFor)
+		} else {
+			m.incUnderrun()
 		}
 	}
 }
 
 func parseArguments(args []string) (config, bool, error) {
 	cfg := config{
-		speed:   defaultSpeed,
-		density: defaultDensity,
+		speed:       defaultSpeed,
+		density:     defaultDensity,
+		paletteSpec: "rainbow",
 	}
 
 	for i := 0; i < len(args); i++ {
@@ -165,6 +236,30 @@ func parseArguments(args []string) (config, bool, error) {
 				return cfg, false, fmt.Errorf("density must be an integer between 1 and 100")
 			}
 			cfg.density = value
+		case "-p", "--palette":
+			if i+1 >= len(args) {
+				return cfg, false, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			cfg.paletteSpec = args[i]
+		case "--effect":
+			if i+1 >= len(args) {
+				return cfg, false, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			cfg.effectSpecs = append(cfg.effectSpecs, args[i])
+		case "--message":
+			if i+1 >= len(args) {
+				return cfg, false, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			cfg.message = args[i]
+		case "--metrics-addr":
+			if i+1 >= len(args) {
+				return cfg, false, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			cfg.metricsAddr = args[i]
 		default:
 			return cfg, false, fmt.Errorf("invalid option: %s", arg)
 		}
@@ -185,15 +280,35 @@ OPTIONS:
               1 = slow, 10 = fast
   -d DENSITY  Column density (1-100%, default: 80)
               Percentage of terminal width filled with columns
+  -p PALETTE  Color palette, default: rainbow
+              rainbow                     sine RGB cycling down each column
+              classic-green                green head with a fading tail
+              monochrome-hex:#rrggbb       a single user-supplied color
+              gradient:#rrggbb,#rrggbb,... smooth HCL blend between stops
+  --effect NAME[:key=val,...]  Layer an overlay effect (repeatable)
+              sine:lambda=8,amp=2,speed=0.1   horizontal wave distortion
+              glitch:chance=0.02,duration=6   random glitch bursts
+              text:message=WAKE UP            centered static text overlay
+  --message TEXT  Shorthand for --effect text:message=TEXT
+  --metrics-addr ADDR  Serve Prometheus metrics at http://ADDR/metrics
   -h          Show this help message
 
 EXAMPLES:
-  matrix-rain                    # Default settings
-  matrix-rain -s 8 -d 100        # Fast animation, full density
-  matrix-rain -s 2 -d 50         # Slow animation, sparse
+  matrix-rain                          # Default settings
+  matrix-rain -s 8 -d 100               # Fast animation, full density
+  matrix-rain -s 2 -d 50                # Slow animation, sparse
+  matrix-rain -p classic-green          # Original Matrix look
+  matrix-rain -p gradient:#39ff14,#003b00  # Custom two-stop gradient
+  matrix-rain --effect glitch:chance=0.05 --message "WAKE UP"
+  matrix-rain --metrics-addr :9100      # Expose metrics for benchmarking
 
 CONTROLS:
-  Ctrl+C      Stop the animation
+  Space       Pause / resume
+  c           Cycle color theme
+  +/-         Increase / decrease speed
+  [/]         Decrease / increase density
+  g           Toggle katakana / ASCII glyphs
+  q, Ctrl+C   Quit
 `)
 }
 
@@ -228,21 +343,17 @@ func calculateColumnCount(width, density int) int {
 	return columns
 }
 
-func initColumns(count, height, rainbowLen int) []column {
-	if rainbowLen == 0 {
-		rainbowLen = 1
-	}
-
+func initColumns(count, height int) []column {
 	cols := make([]column, count)
 	for idx := range cols {
 		cols[idx].gap = rand.Intn(10) + 5
 		cols[idx].length = rand.Intn(height/2+1) + 3
-		cols[idx].colorOffset = rand.Intn(rainbowLen)
+		cols[idx].colorOffset = rand.Intn(rainbowCycle)
 	}
 	return cols
 }
 
-func drawColumnFrame(writer *bufio.Writer, idx int, col *column, height int, rainbowTable []string) {
+func drawColumnFrame(screen *Screen, idx int, col *column, height int, palette Palette, ascii bool, compositor *Compositor) {
 	if !col.active {
 		if col.gap > 0 {
 			col.gap--
@@ -254,25 +365,30 @@ func drawColumnFrame(writer *bufio.Writer, idx int, col *column, height int, rai
 
 	head := col.head
 	length := col.length
-	colorOffset := col.colorOffset
-	prevChar := col.lastChar
 
-	if head >= 1 && head <= height {
-		char := katakanaChars[rand.Intn(len(katakanaChars))]
-		col.lastChar = char
-		color := rainbowColor(rainbowTable, head+colorOffset)
-		fmt.Fprintf(writer, "\033[%d;%dH\033[1;38;2;%sm%s", head, idx+1, color, string(char))
+	glyphs := katakanaChars
+	if ascii {
+		glyphs = asciiGlyphChars
 	}
+	col.pushHead(glyphs[rand.Intn(len(glyphs))])
 
-	trailPos := head - 1
-	if trailPos >= 1 && trailPos <= height && prevChar != 0 {
-		color := rainbowColor(rainbowTable, trailPos+colorOffset)
-		fmt.Fprintf(writer, "\033[%d;%dH\033[2;38;2;%sm%s", trailPos, idx+1, color, string(prevChar))
+	for age, ch := range col.trail {
+		row := head - age
+		if row < 1 || row > height || compositor.Protected(row, idx+1) {
+			continue
+		}
+		if age == 0 {
+			color := palette.Head(col, row)
+			screen.Set(row, idx+1, ch, headSGR(color))
+		} else {
+			color := palette.Trail(col, row, age, length)
+			screen.Set(row, idx+1, ch, trailSGR(color))
+		}
 	}
 
 	erasePos := head - length
-	if erasePos >= 1 && erasePos <= height {
-		fmt.Fprintf(writer, "\033[0m\033[%d;%dH ", erasePos, idx+1)
+	if erasePos >= 1 && erasePos <= height && !compositor.Protected(erasePos, idx+1) {
+		screen.Set(erasePos, idx+1, ' ', "")
 	}
 
 	col.head = head + 1
@@ -282,8 +398,8 @@ func drawColumnFrame(writer *bufio.Writer, idx int, col *column, height int, rai
 		col.head = 0
 		col.gap = rand.Intn(10) + 5
 		col.length = rand.Intn(height/2+1) + 3
-		col.colorOffset = (colorOffset + rand.Intn(len(rainbowTable))) % len(rainbowTable)
-		col.lastChar = 0
+		col.colorOffset = rand.Intn(rainbowCycle)
+		col.trail = col.trail[:0]
 	}
 }
 
@@ -308,12 +424,9 @@ func clampDimensions(width, height int) (int, int) {
 	return width, height
 }
 
-func getTerminalSize() (int, int) {
-	if width, height, ok := ioctlGetWinsize(os.Stdout.Fd()); ok {
-		return width, height
-	}
-	if width, height, ok := ioctlGetWinsize(os.Stdin.Fd()); ok {
-		return width, height
+func getTerminalSize(tty term.Terminal) (int, int) {
+	if size, ok := tty.GetSize(); ok {
+		return size.Width, size.Height
 	}
 
 	width := 80
@@ -332,15 +445,6 @@ func getTerminalSize() (int, int) {
 	return width, height
 }
 
-func ioctlGetWinsize(fd uintptr) (int, int, bool) {
-	ws := &winsize{}
-	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws)))
-	if errno == 0 && ws.col > 0 && ws.row > 0 {
-		return int(ws.col), int(ws.row), true
-	}
-	return 0, 0, false
-}
-
 func setupTerminal(writer *bufio.Writer) {
 	writer.WriteString("\033[?1049h")
 	writer.WriteString("\033[2J")
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Metrics holds the counters/gauges exported at --metrics-addr. A nil
+// *Metrics is a valid receiver: every method is a no-op when the receiver
+// is nil, so callers don't need to branch on whether metrics were enabled.
+// startMetricsServer is not a method and needs a non-nil m.
+type Metrics struct {
+	registry metrics.Registry
+
+	framesRendered     metrics.Counter
+	frameRenderTime    metrics.Timer
+	escapeBytesWritten metrics.Counter
+	activeColumns      metrics.Gauge
+	terminalWidth      metrics.Gauge
+	terminalHeight     metrics.Gauge
+	sleepUnderruns     metrics.Counter
+}
+
+func newMetrics() *Metrics {
+	registry := metrics.NewRegistry()
+	m := &Metrics{
+		registry:           registry,
+		framesRendered:     metrics.NewCounter(),
+		frameRenderTime:    metrics.NewTimer(),
+		escapeBytesWritten: metrics.NewCounter(),
+		activeColumns:      metrics.NewGauge(),
+		terminalWidth:      metrics.NewGauge(),
+		terminalHeight:     metrics.NewGauge(),
+		sleepUnderruns:     metrics.NewCounter(),
+	}
+	registry.Register("matrixrgb_frames_rendered_total", m.framesRendered)
+	registry.Register("matrixrgb_frame_render_seconds", m.frameRenderTime)
+	registry.Register("matrixrgb_escape_bytes_written_total", m.escapeBytesWritten)
+	registry.Register("matrixrgb_active_columns", m.activeColumns)
+	registry.Register("matrixrgb_terminal_width", m.terminalWidth)
+	registry.Register("matrixrgb_terminal_height", m.terminalHeight)
+	registry.Register("matrixrgb_sleep_underruns_total", m.sleepUnderruns)
+	return m
+}
+
+func (m *Metrics) observeFrame(d time.Duration, activeColumns int) {
+	if m == nil {
+		return
+	}
+	m.framesRendered.Inc(1)
+	m.frameRenderTime.Update(d)
+	m.activeColumns.Update(int64(activeColumns))
+}
+
+func (m *Metrics) recordSize(width, height int) {
+	if m == nil {
+		return
+	}
+	m.terminalWidth.Update(int64(width))
+	m.terminalHeight.Update(int64(height))
+}
+
+func (m *Metrics) addBytesWritten(n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.escapeBytesWritten.Inc(int64(n))
+}
+
+func (m *Metrics) incUnderrun() {
+	if m == nil {
+		return
+	}
+	m.sleepUnderruns.Inc(1)
+}
+
+// startMetricsServer starts an HTTP server exporting m in Prometheus text
+// exposition format at /metrics.
+func startMetricsServer(addr string, m *Metrics) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writePrometheus(w, m.registry)
+	})
+
+	go http.Serve(ln, mux)
+	return nil
+}
+
+// writePrometheus renders a go-metrics registry in Prometheus text format.
+func writePrometheus(w http.ResponseWriter, registry metrics.Registry) {
+	registry.Each(func(name string, i interface{}) {
+		switch metric := i.(type) {
+		case metrics.Counter:
+			fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", name, name, metric.Count())
+		case metrics.Gauge:
+			fmt.Fprintf(w, "# TYPE %s gauge\n%s %d\n", name, name, metric.Value())
+		case metrics.Timer:
+			fmt.Fprintf(w, "# TYPE %s summary\n%s_count %d\n%s_sum %f\n", name, name, metric.Count(), name, float64(metric.Sum())/1e9)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Palette supplies the "r;g;b" SGR color components for a column's head and
+// for the glyphs trailing behind it, so effects like a fixed green head
+// with a fading tail are possible alongside per-row hue cycling.
+type Palette interface {
+	// Head returns the color for the newest glyph at the given row.
+	Head(col *column, row int) string
+	// Trail returns the color for a glyph `age` rows behind the head, out
+	// of a trail that is `length` rows long.
+	Trail(col *column, row, age, length int) string
+}
+
+// newPalette builds a Palette from a --palette flag value, e.g. "rainbow",
+// "classic-green", "monochrome-hex:#39ff14", or "gradient:#ff0000,#0000ff".
+func newPalette(spec string) (Palette, error) {
+	name, arg := spec, ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		name, arg = spec[:i], spec[i+1:]
+	}
+
+	switch name {
+	case "", "rainbow":
+		return newRainbowPalette(rainbowFreq, rainbowCycle), nil
+	case "classic-green":
+		return classicGreenPalette{}, nil
+	case "monochrome-hex":
+		if arg == "" {
+			return nil, fmt.Errorf("monochrome-hex palette requires a color, e.g. monochrome-hex:#39ff14")
+		}
+		return newMonochromeHexPalette(arg)
+	case "gradient":
+		stops := strings.Split(arg, ",")
+		if len(stops) < 2 {
+			return nil, fmt.Errorf("gradient palette requires at least two stop colors, e.g. gradient:#ff0000,#0000ff")
+		}
+		return newGradientPalette(stops)
+	default:
+		return nil, fmt.Errorf("unknown palette %q", name)
+	}
+}
+
+// rainbowPalette reproduces the original behavior: a sine-wave RGB table
+// cycled by row position plus a per-column offset.
+type rainbowPalette struct {
+	table []string
+}
+
+func newRainbowPalette(freq float64, cycle int) *rainbowPalette {
+	return &rainbowPalette{table: buildRainbowTable(freq, cycle)}
+}
+
+func (p *rainbowPalette) Head(col *column, row int) string {
+	return rainbowColor(p.table, row+col.colorOffset)
+}
+
+func (p *rainbowPalette) Trail(col *column, row, age, length int) string {
+	return rainbowColor(p.table, row+col.colorOffset)
+}
+
+// classicGreenPalette is the original Matrix look: a bright green head with
+// the tail fading toward black as it nears the end of the trail.
+type classicGreenPalette struct{}
+
+func (classicGreenPalette) Head(col *column, row int) string {
+	return "120;255;120"
+}
+
+func (classicGreenPalette) Trail(col *column, row, age, length int) string {
+	if length < 1 {
+		length = 1
+	}
+	fade := 1 - float64(age)/float64(length)
+	if fade < 0 {
+		fade = 0
+	}
+	g := int(40 + fade*180)
+	return fmt.Sprintf("0;%d;30", g)
+}
+
+// monochromeHexPalette renders every glyph in a single user-supplied color.
+type monochromeHexPalette struct {
+	rgb string
+}
+
+func newMonochromeHexPalette(hex string) (*monochromeHexPalette, error) {
+	c, err := colorful.Hex(hex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid palette color %q: %w", hex, err)
+	}
+	r, g, b := c.RGB255()
+	return &monochromeHexPalette{rgb: fmt.Sprintf("%d;%d;%d", r, g, b)}, nil
+}
+
+func (p *monochromeHexPalette) Head(col *column, row int) string {
+	return p.rgb
+}
+
+func (p *monochromeHexPalette) Trail(col *column, row, age, length int) string {
+	return p.rgb
+}
+
+// gradientPalette interpolates between N stop colors in HCL space for
+// perceptually uniform transitions, cycling by row position like
+// rainbowPalette but smoothly instead of by discrete table lookup.
+type gradientPalette struct {
+	stops []colorful.Color
+}
+
+func newGradientPalette(hexStops []string) (*gradientPalette, error) {
+	stops := make([]colorful.Color, len(hexStops))
+	for i, hex := range hexStops {
+		c, err := colorful.Hex(strings.TrimSpace(hex))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gradient stop %q: %w", hex, err)
+		}
+		stops[i] = c
+	}
+	return &gradientPalette{stops: stops}, nil
+}
+
+func (p *gradientPalette) colorAt(position int) string {
+	t := float64(position%rainbowCycle) / float64(rainbowCycle)
+	t -= math.Floor(t)
+
+	segs := len(p.stops)
+	pos := t * float64(segs)
+	i := int(pos) % segs
+	j := (i + 1) % segs
+	frac := pos - math.Floor(pos)
+
+	blended := p.stops[i].BlendHcl(p.stops[j], frac)
+	r, g, b := blended.RGB255()
+	return fmt.Sprintf("%d;%d;%d", r, g, b)
+}
+
+func (p *gradientPalette) Head(col *column, row int) string {
+	return p.colorAt(row + col.colorOffset)
+}
+
+func (p *gradientPalette) Trail(col *column, row, age, length int) string {
+	return p.colorAt(row + col.colorOffset)
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestGradientPaletteColorAtStops(t *testing.T) {
+	p, err := newGradientPalette([]string{"#ff0000", "#0000ff"})
+	if err != nil {
+		t.Fatalf("newGradientPalette: %v", err)
+	}
+
+	if got, want := p.colorAt(0), "255;0;0"; got != want {
+		t.Errorf("colorAt(0) = %q, want %q (first stop)", got, want)
+	}
+
+	// Position rainbowCycle is one full cycle around from 0 and should land
+	// back on the first stop.
+	if got, want := p.colorAt(rainbowCycle), p.colorAt(0); got != want {
+		t.Errorf("colorAt(rainbowCycle) = %q, want %q (colorAt(0), one cycle later)", got, want)
+	}
+}
+
+func TestGradientPaletteRejectsFewerThanTwoStops(t *testing.T) {
+	if _, err := newPalette("gradient:#ff0000"); err == nil {
+		t.Fatal("expected an error for a gradient with only one stop")
+	}
+}
+
+func TestGradientPaletteRejectsInvalidHex(t *testing.T) {
+	if _, err := newGradientPalette([]string{"#ff0000", "not-a-color"}); err == nil {
+		t.Fatal("expected an error for an invalid hex stop")
+	}
+}
+
+func TestMonochromeHexPalette(t *testing.T) {
+	p, err := newMonochromeHexPalette("#39ff14")
+	if err != nil {
+		t.Fatalf("newMonochromeHexPalette: %v", err)
+	}
+	if got, want := p.Head(nil, 0), "57;255;20"; got != want {
+		t.Errorf("Head = %q, want %q", got, want)
+	}
+	if got, want := p.Trail(nil, 0, 0, 0), p.Head(nil, 0); got != want {
+		t.Errorf("Trail = %q, want same color as Head (%q)", got, want)
+	}
+}
+
+func TestClassicGreenPaletteTrailFadesWithAge(t *testing.T) {
+	p := classicGreenPalette{}
+	head := p.Trail(nil, 0, 0, 10)
+	tail := p.Trail(nil, 0, 9, 10)
+	if head == tail {
+		t.Fatalf("expected trail color to change with age, got %q for both", head)
+	}
+}
+
+func TestNewPaletteUnknownName(t *testing.T) {
+	if _, err := newPalette("not-a-real-palette"); err == nil {
+		t.Fatal("expected an error for an unknown palette name")
+	}
+}
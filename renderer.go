@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"time"
+)
+
+// themeDef names a color theme selectable by cycling with 'c' and the
+// Palette it builds.
+type themeDef struct {
+	name       string
+	newPalette func() Palette
+}
+
+var colorThemes = []themeDef{
+	{"rainbow", func() Palette { return newRainbowPalette(rainbowFreq, rainbowCycle) }},
+	{"classic-green", func() Palette { return classicGreenPalette{} }},
+	{"fire", func() Palette { return newRainbowPalette(0.25, rainbowCycle) }},
+	{"ocean", func() Palette { return newRainbowPalette(0.04, rainbowCycle) }},
+}
+
+// Renderer owns the animation state and drives the frame loop. Pulling this
+// out of main lets an input goroutine mutate speed, density, theme, and
+// glyph set while the animation keeps running.
+type Renderer struct {
+	writer *bufio.Writer
+	cfg    config
+
+	width  int
+	height int
+
+	columns    []column
+	screen     *Screen
+	palette    Palette
+	compositor *Compositor
+	metrics    *Metrics
+	frame      int
+	themeIdx   int
+	ascii      bool
+	paused     bool
+}
+
+func newRenderer(writer *bufio.Writer, cfg config, width, height int, palette Palette, compositor *Compositor, m *Metrics) *Renderer {
+	r := &Renderer{writer: writer, cfg: cfg, palette: palette, compositor: compositor, metrics: m}
+	r.resize(width, height)
+	return r
+}
+
+// resize reallocates columns for a new terminal size or density. Callers
+// that only change speed, theme, or glyph set should not call this.
+func (r *Renderer) resize(width, height int) {
+	width, height = clampDimensions(width, height)
+	r.width, r.height = width, height
+	numColumns := calculateColumnCount(width, r.cfg.density)
+	r.columns = initColumns(numColumns, height)
+	r.screen = newScreen(width, height)
+	r.metrics.recordSize(width, height)
+}
+
+// applyCommand mutates the renderer in response to a keypress. It reports
+// whether the renderer should keep running.
+func (r *Renderer) applyCommand(cmd command) bool {
+	switch cmd {
+	case cmdTogglePause:
+		r.paused = !r.paused
+	case cmdNextTheme:
+		r.themeIdx = (r.themeIdx + 1) % len(colorThemes)
+		r.palette = colorThemes[r.themeIdx].newPalette()
+	case cmdSpeedUp:
+		if r.cfg.speed < 10 {
+			r.cfg.speed++
+		}
+	case cmdSpeedDown:
+		if r.cfg.speed > 1 {
+			r.cfg.speed--
+		}
+	case cmdDensityUp:
+		if r.cfg.density < 100 {
+			r.cfg.density++
+			r.resize(r.width, r.height)
+		}
+	case cmdDensityDown:
+		if r.cfg.density > 1 {
+			r.cfg.density--
+			r.resize(r.width, r.height)
+		}
+	case cmdToggleGlyphs:
+		r.ascii = !r.ascii
+	case cmdQuit:
+		return false
+	}
+	return true
+}
+
+func (r *Renderer) frameDelay() time.Duration {
+	return calculateFrameDelay(r.cfg.speed)
+}
+
+func (r *Renderer) drawFrame() {
+	if r.paused {
+		return
+	}
+
+	renderStart := time.Now()
+
+	active := 0
+	for idx := range r.columns {
+		drawColumnFrame(r.screen, idx, &r.columns[idx], r.height, r.palette, r.ascii, r.compositor)
+		if r.columns[idx].active {
+			active++
+		}
+	}
+	r.compositor.Apply(r.screen, r.frame)
+	r.frame++
+
+	r.screen.Flush(r.writer, r.metrics)
+	r.metrics.observeFrame(time.Since(renderStart), active)
+}
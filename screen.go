@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// cell is one character cell: its glyph and the fully-formed SGR escape
+// that should precede it ("" means no color, i.e. a reset/blank cell).
+type cell struct {
+	ch  rune
+	sgr string
+}
+
+// Screen is a back-buffer/front-buffer pair. Columns draw into the back
+// buffer every frame; Flush compares it against what was last actually
+// written to the terminal (the front buffer) and only emits escapes for
+// cells that changed, coalescing adjacent same-attribute runs.
+type Screen struct {
+	width  int
+	height int
+	back   []cell
+	front  []cell
+}
+
+func newScreen(width, height int) *Screen {
+	return &Screen{
+		width:  width,
+		height: height,
+		back:   make([]cell, width*height),
+		front:  make([]cell, width*height),
+	}
+}
+
+func (s *Screen) index(row, col int) int {
+	return (row-1)*s.width + (col - 1)
+}
+
+// Set stages a glyph at the given 1-indexed row/col for the next Flush.
+// Out-of-bounds positions are ignored.
+func (s *Screen) Set(row, col int, ch rune, sgr string) {
+	if row < 1 || row > s.height || col < 1 || col > s.width {
+		return
+	}
+	s.back[s.index(row, col)] = cell{ch: ch, sgr: sgr}
+}
+
+// Get returns the currently staged (back buffer) cell at row/col, so
+// effects can read what the rain already drew this frame.
+func (s *Screen) Get(row, col int) (cell, bool) {
+	if row < 1 || row > s.height || col < 1 || col > s.width {
+		return cell{}, false
+	}
+	return s.back[s.index(row, col)], true
+}
+
+// shiftRow moves every cell in row sideways by shift columns, clipping
+// cells that fall off either edge and blanking the vacated columns.
+func (s *Screen) shiftRow(row, shift int) {
+	if row < 1 || row > s.height {
+		return
+	}
+	base := (row - 1) * s.width
+
+	orig := make([]cell, s.width)
+	copy(orig, s.back[base:base+s.width])
+
+	for col := 0; col < s.width; col++ {
+		s.back[base+col] = cell{ch: ' '}
+	}
+	for col, c := range orig {
+		newCol := col + shift
+		if newCol < 0 || newCol >= s.width {
+			continue
+		}
+		s.back[base+newCol] = c
+	}
+}
+
+// run is a contiguous stretch of changed cells on one row sharing an SGR,
+// written with a single cursor move instead of one per cell.
+type run struct {
+	row      int
+	startCol int
+	sgr      string
+	chars    []rune
+}
+
+// Flush writes only the cells that differ from the last Flush to writer,
+// then copies the back buffer into the front buffer. Bytes written are
+// reported to m (a nil m is fine; the count is simply discarded).
+func (s *Screen) Flush(writer *bufio.Writer, m *Metrics) {
+	var currentSGR string
+	haveSGR := false
+	var open *run
+	written := 0
+
+	emit := func() {
+		if open == nil {
+			return
+		}
+		n, _ := fmt.Fprintf(writer, "\033[%d;%dH", open.row, open.startCol)
+		written += n
+		if !haveSGR || currentSGR != open.sgr {
+			if open.sgr == "" {
+				n, _ = writer.WriteString("\033[0m")
+			} else {
+				n, _ = writer.WriteString(open.sgr)
+			}
+			written += n
+			currentSGR = open.sgr
+			haveSGR = true
+		}
+		n, _ = writer.WriteString(string(open.chars))
+		written += n
+		open = nil
+	}
+
+	for row := 1; row <= s.height; row++ {
+		for col := 1; col <= s.width; col++ {
+			idx := s.index(row, col)
+			back := s.back[idx]
+			if back == s.front[idx] {
+				emit()
+				continue
+			}
+			if open != nil && open.row == row && open.sgr == back.sgr && open.startCol+len(open.chars) == col {
+				open.chars = append(open.chars, back.ch)
+			} else {
+				emit()
+				open = &run{row: row, startCol: col, sgr: back.sgr, chars: []rune{back.ch}}
+			}
+			s.front[idx] = back
+		}
+		emit()
+	}
+
+	n, _ := writer.WriteString("\033[0m")
+	written += n
+	writer.Flush()
+
+	m.addBytesWritten(written)
+}
+
+func headSGR(color string) string {
+	return fmt.Sprintf("\033[1;38;2;%sm", color)
+}
+
+func trailSGR(color string) string {
+	return fmt.Sprintf("\033[2;38;2;%sm", color)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScreenFlushOnlyEmitsChangedCells(t *testing.T) {
+	s := newScreen(5, 2)
+	s.Set(1, 1, 'A', "1;38;2;1;2;3")
+	s.Set(1, 2, 'B', "1;38;2;1;2;3")
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	s.Flush(w, nil)
+
+	out := buf.String()
+	if !strings.Contains(out, "AB") {
+		t.Fatalf("expected coalesced run \"AB\" in output, got %q", out)
+	}
+	if strings.Count(out, "1;38;2;1;2;3") != 1 {
+		t.Fatalf("expected a single SGR escape for the coalesced run, got %q", out)
+	}
+
+	// Nothing changed since the last Flush, so the second pass should emit
+	// no cursor moves or glyphs, just the trailing reset.
+	buf.Reset()
+	s.Flush(w, nil)
+	if got := buf.String(); got != "\033[0m" {
+		t.Fatalf("expected no-op flush to emit only the trailing reset, got %q", got)
+	}
+}
+
+func TestScreenFlushBreaksRunsOnSGRChange(t *testing.T) {
+	s := newScreen(3, 1)
+	s.Set(1, 1, 'A', "red")
+	s.Set(1, 2, 'B', "blue")
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	s.Flush(w, nil)
+
+	out := buf.String()
+	if !strings.Contains(out, "red") || !strings.Contains(out, "blue") {
+		t.Fatalf("expected both SGR escapes present, got %q", out)
+	}
+	if strings.Index(out, "red") > strings.Index(out, "blue") {
+		t.Fatalf("expected \"red\" run before \"blue\" run, got %q", out)
+	}
+}
+
+func TestScreenShiftRow(t *testing.T) {
+	s := newScreen(4, 1)
+	s.Set(1, 1, 'A', "")
+	s.Set(1, 2, 'B', "")
+	s.Set(1, 3, 'C', "")
+	s.Set(1, 4, 'D', "")
+
+	s.shiftRow(1, 1)
+
+	wantCh := []rune{' ', 'A', 'B', 'C'}
+	for col, want := range wantCh {
+		c, ok := s.Get(1, col+1)
+		if !ok || c.ch != want {
+			t.Fatalf("col %d: got %q, want %q", col+1, c.ch, want)
+		}
+	}
+
+	// A cell shifted past the right edge is dropped, not wrapped.
+	s.shiftRow(1, 10)
+	for col := 1; col <= 4; col++ {
+		c, _ := s.Get(1, col)
+		if c.ch != ' ' {
+			t.Fatalf("col %d: expected blank after large shift, got %q", col, c.ch)
+		}
+	}
+}
+
+func TestScreenSetGetOutOfBounds(t *testing.T) {
+	s := newScreen(2, 2)
+	s.Set(0, 1, 'X', "")
+	s.Set(1, 3, 'X', "")
+	if _, ok := s.Get(0, 1); ok {
+		t.Fatal("expected Get to report out-of-bounds row as not ok")
+	}
+	if _, ok := s.Get(1, 3); ok {
+		t.Fatal("expected Get to report out-of-bounds col as not ok")
+	}
+}